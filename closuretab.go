@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -29,23 +30,113 @@ type Node struct {
 	Depth    int
 }
 
+// NodeWithPath is a Node annotated with its ordered path from the root
+// a GetSubtree call was issued against, and its depth relative to that root.
+type NodeWithPath struct {
+	ID       int64
+	ParentID int64
+	Path     []int64
+	Level    int
+}
+
+// TreeNode groups a flat []NodeWithPath into a nested structure suitable
+// for serialising a subtree in a single pass, e.g. for a JSON API response.
+type TreeNode struct {
+	Node     NodeWithPath
+	Children []*TreeNode
+}
+
 type ClosureRelation struct {
-	table string
-	attrs map[AttrType]string
+	table        string
+	attrs        map[AttrType]string
+	dialect      Dialect
+	serializable bool
+}
+
+type Option func(*ClosureRelation)
+
+// WithDialect selects the SQL dialect used to build queries that require
+// database-specific syntax. Defaults to DialectSQLite.
+func WithDialect(d Dialect) Option {
+	return func(r *ClosureRelation) {
+		r.dialect = d
+	}
+}
+
+// WithSerializableMoves makes Move open its transaction at
+// sql.LevelSerializable and lock the moved node's row with SELECT ... FOR
+// UPDATE before reading its parents and children, so concurrent Moves of
+// the same node can't race each other into an inconsistent closure table.
+// Requires a dialect/driver that supports row locking (Postgres, MySQL);
+// SQLite doesn't.
+func WithSerializableMoves() Option {
+	return func(r *ClosureRelation) {
+		r.serializable = true
+	}
+}
+
+func InitClosureRelation(tableName string, attrs AttrMapping, opts ...Option) *ClosureRelation {
+	r := &ClosureRelation{table: tableName, attrs: attrs}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.dialect == nil {
+		r.dialect = DialectSQLite
+	}
+	return r
+}
+
+// qTable returns the closure table's name, quoted for r's dialect.
+func (r *ClosureRelation) qTable() string {
+	return r.dialect.QuoteIdent(r.table)
+}
+
+// qAttr returns the column name mapped to t, quoted for r's dialect.
+func (r *ClosureRelation) qAttr(t AttrType) string {
+	return r.dialect.QuoteIdent(r.attrs[t])
+}
+
+// bind rewrites a query built with "?" placeholders into r's dialect.
+func (r *ClosureRelation) bind(query string) string {
+	return rebind(r.dialect, query)
 }
 
-func InitClosureRelation(tableName string, attrs AttrMapping) *ClosureRelation {
-	return &ClosureRelation{table: tableName, attrs: attrs}
+// txOptions returns the *sql.TxOptions operations should open their
+// transaction with, reflecting WithSerializableMoves.
+func (r *ClosureRelation) txOptions() *sql.TxOptions {
+	if r.serializable {
+		return &sql.TxOptions{Isolation: sql.LevelSerializable}
+	}
+	return nil
+}
+
+// lockNodeForUpdate takes a row lock on nodeID's self-reference row, so
+// concurrent Moves of the same node serialize against each other. Only
+// meaningful for dialects/drivers that support SELECT ... FOR UPDATE.
+func (r *ClosureRelation) lockNodeForUpdate(ctx context.Context, q Querier, nodeID int64) error {
+	row := q.QueryRowContext(
+		ctx,
+		r.bind(fmt.Sprintf(
+			"SELECT %s FROM %s WHERE %s = ? AND %s = 0 FOR UPDATE",
+			r.qAttr(Child), r.qTable(), r.qAttr(Child), r.qAttr(Depth),
+		)),
+		nodeID,
+	)
+	var id int64
+	if err := row.Scan(&id); err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("lock node ID %d for update: %w", nodeID, err)
+	}
+	return nil
 }
 
 func (r *ClosureRelation) GetChildren(ctx context.Context, q Querier, parentID int64) ([]Node, error) {
 	rows, err := q.QueryContext(
 		ctx,
-		fmt.Sprintf(
+		r.bind(fmt.Sprintf(
 			"SELECT %s, %s, %s FROM %s WHERE %s = ? ORDER BY %s ASC",
-			r.attrs[Child], r.attrs[Parent], r.attrs[Depth], r.table,
-			r.attrs[Parent], r.attrs[Depth],
-		),
+			r.qAttr(Child), r.qAttr(Parent), r.qAttr(Depth), r.qTable(),
+			r.qAttr(Parent), r.qAttr(Depth),
+		)),
 		parentID,
 	)
 	if err != nil {
@@ -57,12 +148,12 @@ func (r *ClosureRelation) GetChildren(ctx context.Context, q Querier, parentID i
 func (r *ClosureRelation) GetParents(ctx context.Context, q Querier, nodeID int64) ([]Node, error) {
 	rows, err := q.QueryContext(
 		ctx,
-		fmt.Sprintf(
+		r.bind(fmt.Sprintf(
 			"SELECT %s, %s, %s FROM %s WHERE %s = ? AND %s != ? ORDER BY %s DESC",
-			r.attrs[Parent], r.attrs[Parent], r.attrs[Depth], r.table,
-			r.attrs[Child], r.attrs[Parent],
-			r.attrs[Depth],
-		),
+			r.qAttr(Parent), r.qAttr(Parent), r.qAttr(Depth), r.qTable(),
+			r.qAttr(Child), r.qAttr(Parent),
+			r.qAttr(Depth),
+		)),
 		nodeID, nodeID,
 	)
 	if err != nil {
@@ -71,134 +162,672 @@ func (r *ClosureRelation) GetParents(ctx context.Context, q Querier, nodeID int6
 	return scanNodes(rows)
 }
 
+// GetSubtree returns every descendant of rootID, each annotated with its
+// ordered path of node IDs from rootID's direct child down to the node
+// itself (rootID is not included) and a Level starting at 1 for rootID's
+// direct children. Results are ordered by level, then parent_id,
+// then node_id, so a parent always precedes its own children and the
+// caller can render a tree in a single pass without issuing further
+// queries; this is a numeric order, not an order over the path string
+// (which would sort multi-digit IDs lexicographically).
+//
+// It works by walking the depth=1 edges of the closure table with a
+// recursive CTE rather than relying on the closure table's own transitive
+// rows, since those don't carry ordering information.
+func (r *ClosureRelation) GetSubtree(ctx context.Context, q Querier, rootID int64) ([]NodeWithPath, error) {
+	seedPath, stepPath, finalPath := r.dialect.PathExprs(r.qAttr(Child))
+	query := r.bind(fmt.Sprintf(
+		`%[8]s subtree AS (
+			SELECT %[1]s AS node_id, %[2]s AS parent_id, %[5]s AS path, 1 AS level
+			FROM %[3]s
+			WHERE %[2]s = ? AND %[4]s = 1
+			UNION ALL
+			SELECT e.%[1]s, e.%[2]s, %[6]s, s.level + 1
+			FROM %[3]s e
+			JOIN subtree s ON e.%[2]s = s.node_id
+			WHERE e.%[4]s = 1
+		)
+		SELECT node_id, parent_id, %[7]s, level FROM subtree ORDER BY level, parent_id, node_id`,
+		r.qAttr(Child), r.qAttr(Parent), r.qTable(), r.qAttr(Depth), seedPath, stepPath, finalPath,
+		r.dialect.RecursiveCTE(),
+	))
+
+	rows, err := q.QueryContext(ctx, query, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("get subtree for root ID %d: %w", rootID, err)
+	}
+	return scanNodesWithPath(rows)
+}
+
+// BuildTree groups a flat []NodeWithPath, as returned by GetSubtree, into a
+// nested tree of TreeNode. Nodes whose parent isn't present in nodes (the
+// root's direct children) become top-level entries in the returned slice.
+func BuildTree(nodes []NodeWithPath) []*TreeNode {
+	byID := make(map[int64]*TreeNode, len(nodes))
+	for i := range nodes {
+		byID[nodes[i].ID] = &TreeNode{Node: nodes[i]}
+	}
+
+	roots := make([]*TreeNode, 0)
+	for i := range nodes {
+		tn := byID[nodes[i].ID]
+		if parent, ok := byID[nodes[i].ParentID]; ok {
+			parent.Children = append(parent.Children, tn)
+			continue
+		}
+		roots = append(roots, tn)
+	}
+	return roots
+}
+
 func (r *ClosureRelation) Insert(ctx context.Context, q Querier, parentID, nodeID int64) (Node, error) {
-	_, err := q.ExecContext(
-		ctx,
-		fmt.Sprintf(
-			"INSERT INTO %s (%s, %s, %s) "+
-				"SELECT ?, %s, %s + 1 FROM %s WHERE %s = ?",
-			r.table, r.attrs[Child], r.attrs[Parent], r.attrs[Depth],
-			r.attrs[Parent], r.attrs[Depth], r.table, r.attrs[Child],
-		),
-		nodeID, parentID,
-	)
+	err := withOptionalTx(ctx, q, nil, func(tq Querier) error {
+		_, err := tq.ExecContext(
+			ctx,
+			r.bind(fmt.Sprintf(
+				"INSERT INTO %s (%s, %s, %s) "+
+					"SELECT ?, %s, %s + 1 FROM %s WHERE %s = ?",
+				r.qTable(), r.qAttr(Child), r.qAttr(Parent), r.qAttr(Depth),
+				r.qAttr(Parent), r.qAttr(Depth), r.qTable(), r.qAttr(Child),
+			)),
+			nodeID, parentID,
+		)
+		if err != nil {
+			return fmt.Errorf("insert hierarchy references: %w", err)
+		}
+
+		_, err = tq.ExecContext(
+			ctx,
+			r.bind(fmt.Sprintf(
+				"INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?)",
+				r.qTable(), r.qAttr(Child), r.qAttr(Parent), r.qAttr(Depth),
+			)),
+			nodeID, nodeID, 0,
+		)
+		if err != nil {
+			return fmt.Errorf("insert self-reference: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Node{}, err
+	}
+	return Node{}, nil
+}
+
+// defaultBatchSize is the number of rows InsertBatch and BulkLoad combine
+// into a single multi-row statement when the caller doesn't pick their own.
+const defaultBatchSize = 500
+
+// InsertBatch inserts many depth=1 edges at once: it loads each referenced
+// parent's existing ancestor rows (loadAncestors), derives each edge's
+// ancestor rows incrementally in Go extending them edge by edge so later
+// edges see the rows computed for earlier ones, and writes the result as
+// multi-row INSERTs chunked to batchSize *rows*, not batchSize edges —
+// a single edge deep in a tall tree can expand into thousands of ancestor
+// rows, so chunking by edge count doesn't bound statement size the way
+// chunking by row count does. edges must be ordered topologically (a
+// node's parent edge must already be committed, either earlier in edges or
+// in a prior InsertBatch call) since each row's ancestor set is derived
+// from what's already in the table or earlier in this call. batchSize <= 0
+// uses defaultBatchSize.
+func (r *ClosureRelation) InsertBatch(ctx context.Context, q Querier, edges []Edge, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+
+	parentIDs := make([]int64, 0, len(edges))
+	seenParent := make(map[int64]bool, len(edges))
+	for _, e := range edges {
+		if !seenParent[e.ParentID] {
+			seenParent[e.ParentID] = true
+			parentIDs = append(parentIDs, e.ParentID)
+		}
+	}
+
+	ancestorsOf, err := r.loadAncestors(ctx, q, parentIDs, batchSize)
 	if err != nil {
-		return Node{}, fmt.Errorf("insert hierarchy references: %w", err)
+		return fmt.Errorf("load existing ancestors: %w", err)
 	}
 
-	_, err = q.ExecContext(
-		ctx,
-		fmt.Sprintf(
-			"INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?)",
-			r.table, r.attrs[Child], r.attrs[Parent], r.attrs[Depth],
-		),
-		nodeID, nodeID, 0,
-	)
+	rows := make([]closureRow, 0, batchSize)
+	for _, e := range edges {
+		childRows := make([]closureRow, 0, len(ancestorsOf[e.ParentID])+1)
+		childRows = append(childRows, closureRow{child: e.ChildID, parent: e.ChildID, depth: 0})
+		for _, a := range ancestorsOf[e.ParentID] {
+			childRows = append(childRows, closureRow{child: e.ChildID, parent: a.parent, depth: a.depth + 1})
+		}
+		rows = append(rows, childRows...)
+		ancestorsOf[e.ChildID] = childRows
+
+		for len(rows) >= batchSize {
+			if err := r.insertClosureRows(ctx, q, rows[:batchSize]); err != nil {
+				return fmt.Errorf("insert batch rows: %w", err)
+			}
+			rows = rows[batchSize:]
+		}
+	}
+	if err := r.insertClosureRows(ctx, q, rows); err != nil {
+		return fmt.Errorf("insert batch rows: %w", err)
+	}
+	return nil
+}
+
+// loadAncestors returns, for each ID in parentIDs, the rows already stored
+// under child=id: its self-row plus one row per existing ancestor. An ID
+// with no rows yet (a brand-new root) is simply absent from the result,
+// which InsertBatch treats as an empty ancestor set. The lookup is chunked
+// to batchSize IDs per query, for the same reason InsertBatch chunks its
+// inserts: a single IN (...) over every parent referenced by a large edge
+// list can itself exceed the driver's bound-parameter limit.
+func (r *ClosureRelation) loadAncestors(ctx context.Context, q Querier, parentIDs []int64, batchSize int) (map[int64][]closureRow, error) {
+	ancestors := make(map[int64][]closureRow, len(parentIDs))
+	for start := 0; start < len(parentIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(parentIDs) {
+			end = len(parentIDs)
+		}
+		chunk := parentIDs[start:end]
+
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+
+		rows, err := q.QueryContext(
+			ctx,
+			r.bind(fmt.Sprintf(
+				"SELECT %s, %s, %s FROM %s WHERE %s IN %s",
+				r.qAttr(Child), r.qAttr(Parent), r.qAttr(Depth), r.qTable(), r.qAttr(Child),
+				makePlaceholders("?", len(chunk)),
+			)),
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := scanAncestorRows(rows, ancestors); err != nil {
+			return nil, err
+		}
+	}
+	return ancestors, nil
+}
+
+func scanAncestorRows(rows *sql.Rows, ancestors map[int64][]closureRow) error {
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var cr closureRow
+		if err := rows.Scan(&cr.child, &cr.parent, &cr.depth); err != nil {
+			return err
+		}
+		ancestors[cr.child] = append(ancestors[cr.child], cr)
+	}
+	return rows.Err()
+}
+
+type closureRow struct {
+	child, parent int64
+	depth         int
+}
+
+// BulkLoad ingests an arbitrary edge list for an initial import: it
+// topologically sorts edges in Go, derives the full transitive closure via
+// DFS from each node, and writes the result as chunked multi-row INSERTs
+// inside a single transaction. Unlike InsertBatch, edges need not be
+// pre-sorted and the closure table is expected to be empty beforehand.
+// batchSize <= 0 uses defaultBatchSize.
+func (r *ClosureRelation) BulkLoad(ctx context.Context, db *sql.DB, edges []Edge, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	sorted, err := topoSort(edges)
 	if err != nil {
-		return Node{}, fmt.Errorf("insert self-reference: %w", err)
+		return fmt.Errorf("topologically sort edges: %w", err)
 	}
 
-	return Node{}, nil
+	rows := buildTransitiveClosure(sorted)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin bulk load transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for start := 0; start < len(rows); start += batchSize {
+		end := start + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		if err := r.insertClosureRows(ctx, tx, rows[start:end]); err != nil {
+			return fmt.Errorf("insert bulk rows [%d:%d): %w", start, end, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit bulk load transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *ClosureRelation) insertClosureRows(ctx context.Context, q Querier, rows []closureRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	values := make([]string, len(rows))
+	args := make([]interface{}, 0, len(rows)*3)
+	for i, row := range rows {
+		values[i] = "(?, ?, ?)"
+		args = append(args, row.child, row.parent, row.depth)
+	}
+
+	query := r.bind(fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s) VALUES %s",
+		r.qTable(), r.qAttr(Child), r.qAttr(Parent), r.qAttr(Depth), strings.Join(values, ", "),
+	))
+	if _, err := q.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("insert closure rows: %w", err)
+	}
+	return nil
+}
+
+// topoSort orders edges so that every node's incoming edges precede its
+// outgoing ones, returning an error if edges contains a cycle.
+func topoSort(edges []Edge) ([]Edge, error) {
+	ids := make(map[int64]struct{})
+	indegree := make(map[int64]int)
+	edgesByParent := make(map[int64][]Edge)
+	for _, e := range edges {
+		ids[e.ParentID] = struct{}{}
+		ids[e.ChildID] = struct{}{}
+		indegree[e.ChildID]++
+		edgesByParent[e.ParentID] = append(edgesByParent[e.ParentID], e)
+	}
+
+	queue := make([]int64, 0)
+	for id := range ids {
+		if indegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	sorted := make([]Edge, 0, len(edges))
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, e := range edgesByParent[id] {
+			sorted = append(sorted, e)
+			indegree[e.ChildID]--
+			if indegree[e.ChildID] == 0 {
+				queue = append(queue, e.ChildID)
+			}
+		}
+	}
+
+	if len(sorted) != len(edges) {
+		return nil, fmt.Errorf("edges contain a cycle")
+	}
+	return sorted, nil
+}
+
+// buildTransitiveClosure derives every ancestor/descendant closure row from
+// a topologically sorted depth=1 edge list, by walking the descendants of
+// each node and recording its distance from that node.
+func buildTransitiveClosure(edges []Edge) []closureRow {
+	children := make(map[int64][]int64, len(edges))
+	ids := make(map[int64]struct{}, len(edges)*2)
+	for _, e := range edges {
+		children[e.ParentID] = append(children[e.ParentID], e.ChildID)
+		ids[e.ParentID] = struct{}{}
+		ids[e.ChildID] = struct{}{}
+	}
+
+	rows := make([]closureRow, 0, len(ids))
+	var walk func(ancestor, node int64, depth int)
+	walk = func(ancestor, node int64, depth int) {
+		rows = append(rows, closureRow{child: node, parent: ancestor, depth: depth})
+		for _, child := range children[node] {
+			walk(ancestor, child, depth+1)
+		}
+	}
+	for id := range ids {
+		walk(id, id, 0)
+	}
+	return rows
 }
 
 func (r *ClosureRelation) Delete(ctx context.Context, q Querier, nodeID int64) error {
-	if _, err := q.ExecContext(
+	return withOptionalTx(ctx, q, nil, func(tq Querier) error {
+		if _, err := tq.ExecContext(
+			ctx,
+			r.bind(fmt.Sprintf(
+				"DELETE FROM %s WHERE %s IN (SELECT %s FROM %s WHERE %s = ?)",
+				r.qTable(), r.qAttr(Child), r.qAttr(Child), r.qTable(), r.qAttr(Parent),
+			)),
+			nodeID,
+		); err != nil {
+			return fmt.Errorf("remove node ID %d: %w", nodeID, err)
+		}
+
+		if _, err := tq.ExecContext(
+			ctx,
+			r.bind(fmt.Sprintf(
+				"DELETE FROM %s WHERE %s = ? OR %s = ?",
+				r.qTable(), r.qAttr(Child), r.qAttr(Parent),
+			)),
+			nodeID, nodeID,
+		); err != nil {
+			return fmt.Errorf("remove node ID %d: %w", nodeID, err)
+		}
+		return nil
+	})
+}
+
+func (r *ClosureRelation) Move(ctx context.Context, q Querier, nodeID, newParentID int64) error {
+	return withOptionalTx(ctx, q, r.txOptions(), func(tq Querier) error {
+		if r.serializable {
+			if err := r.lockNodeForUpdate(ctx, tq, nodeID); err != nil {
+				return err
+			}
+		}
+
+		children, err := r.GetChildren(ctx, tq, nodeID)
+		if err != nil {
+			return fmt.Errorf("get all nodes being moved: %w", err)
+		}
+		for _, child := range children {
+			if child.ID == newParentID {
+				return fmt.Errorf(
+					"move node ID %d: new parent %d is a descendant of the moved node",
+					nodeID, newParentID,
+				)
+			}
+		}
+
+		if _, deleteErr := tq.ExecContext(
+			ctx,
+			r.bind(fmt.Sprintf(
+				"DELETE FROM %s "+
+					"WHERE %s IN "+
+					"(SELECT %s FROM %s WHERE %s = ?) "+
+					"AND %s IN "+
+					"(SELECT %s FROM %s WHERE %s = ? AND %s != %s) ",
+				r.qTable(),
+				r.qAttr(Child),
+				r.qAttr(Child), r.qTable(), r.qAttr(Parent),
+				r.qAttr(Parent),
+				r.qAttr(Parent), r.qTable(), r.qAttr(Child), r.qAttr(Parent), r.qAttr(Child),
+			)),
+			nodeID, nodeID,
+		); deleteErr != nil {
+			return fmt.Errorf("remove node ID %d: %w", nodeID, deleteErr)
+		}
+
+		parents, err := r.GetParents(ctx, tq, newParentID)
+		if err != nil {
+			return fmt.Errorf("get new parents for moved nodes: %w", err)
+		}
+		parentIDs := NodeIDs(parents)
+		parentIDs = append(parentIDs, newParentID)
+		parentIDsPlaceholders := makePlaceholders("?", len(parentIDs))
+
+		childrenIDs := NodeIDs(children)
+		childrenIDsPlaceholders := makePlaceholders("?", len(childrenIDs))
+
+		args := make([]interface{}, len(parentIDs)+len(childrenIDs))
+		for i := 0; i < len(args); i++ {
+			if i < len(parentIDs) {
+				args[i] = parentIDs[i]
+			} else {
+				args[i] = childrenIDs[i-len(parentIDs)]
+			}
+		}
+
+		query := r.bind(fmt.Sprintf(
+			`INSERT INTO %s (%s, %s, %s)
+	        	SELECT supertree.%s, subtree.%s, MAX(supertree.%s + subtree.%s + 1)
+	        	FROM %s AS supertree, %s AS subtree
+	        	WHERE
+	        	    supertree.%s IN %s
+	        		AND subtree.%s IN %s
+				GROUP BY supertree.%s, subtree.%s`,
+			r.qTable(), r.qAttr(Parent), r.qAttr(Child), r.qAttr(Depth),
+			r.qAttr(Parent), r.qAttr(Child), r.qAttr(Depth), r.qAttr(Depth),
+			r.qTable(), r.qTable(),
+			r.qAttr(Parent), parentIDsPlaceholders,
+			r.qAttr(Child), childrenIDsPlaceholders,
+			r.qAttr(Parent), r.qAttr(Child),
+		))
+
+		if _, insertErr := tq.ExecContext(
+			ctx,
+			query,
+			args...,
+		); insertErr != nil {
+			return fmt.Errorf("insert nodes under new parent: %w", insertErr)
+		}
+
+		return nil
+	})
+}
+
+// Edge is a direct (depth=1) parent/child relationship.
+type Edge struct {
+	ParentID int64
+	ChildID  int64
+}
+
+// IntegrityReport summarises the structural problems found by Validate.
+// A report with every field empty means the closure table is consistent.
+type IntegrityReport struct {
+	// MissingSelfRefs are node IDs without a depth=0 self-reference row.
+	MissingSelfRefs []int64
+	// DuplicateEdges are (parent, child) pairs that appear more than once.
+	DuplicateEdges []Edge
+	// Orphaned are node IDs not reachable from any root via depth=1 edges.
+	Orphaned []int64
+	// Cycles are node ID sequences reachable from themselves via depth=1 edges.
+	Cycles [][]int64
+}
+
+// Clean reports whether the table has no detected integrity problems.
+func (rep *IntegrityReport) Clean() bool {
+	return len(rep.MissingSelfRefs) == 0 && len(rep.DuplicateEdges) == 0 &&
+		len(rep.Orphaned) == 0 && len(rep.Cycles) == 0
+}
+
+// Validate scans the whole closure table and reports missing self-references,
+// duplicate edges, rows unreachable from any root, and cycles among the
+// depth=1 edges.
+func (r *ClosureRelation) Validate(ctx context.Context, q Querier) (*IntegrityReport, error) {
+	rows, err := q.QueryContext(
 		ctx,
 		fmt.Sprintf(
-			"DELETE FROM %s WHERE %s IN (SELECT %s FROM %s WHERE %s = ?)",
-			r.table, r.attrs[Child], r.attrs[Child], r.table, r.attrs[Parent],
+			"SELECT %s, %s, %s FROM %s",
+			r.qAttr(Child), r.qAttr(Parent), r.qAttr(Depth), r.qTable(),
 		),
-		nodeID,
-	); err != nil {
-		return fmt.Errorf("remove node ID %d: %w", nodeID, err)
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load closure rows: %w", err)
+	}
+	all, err := scanNodes(rows)
+	if err != nil {
+		return nil, fmt.Errorf("scan closure rows: %w", err)
 	}
 
-	if _, err := q.ExecContext(
-		ctx,
-		fmt.Sprintf(
-			"DELETE FROM %s WHERE %s = ? OR %s = ?",
-			r.table, r.attrs[Child], r.attrs[Parent],
-		),
-		nodeID, nodeID,
-	); err != nil {
-		return fmt.Errorf("remove node ID %d: %w", nodeID, err)
+	ids := make(map[int64]struct{})
+	selfRef := make(map[int64]bool)
+	pairCounts := make(map[Edge]int)
+	directChildren := make(map[int64][]int64)
+	directParent := make(map[int64]int64)
+
+	for _, n := range all {
+		ids[n.ID] = struct{}{}
+		ids[n.ParentID] = struct{}{}
+		pairCounts[Edge{ParentID: n.ParentID, ChildID: n.ID}]++
+		if n.Depth == 0 && n.ID == n.ParentID {
+			selfRef[n.ID] = true
+		}
+		if n.Depth == 1 {
+			directChildren[n.ParentID] = append(directChildren[n.ParentID], n.ID)
+			directParent[n.ID] = n.ParentID
+		}
 	}
-	return nil
+
+	report := &IntegrityReport{}
+	for id := range ids {
+		if !selfRef[id] {
+			report.MissingSelfRefs = append(report.MissingSelfRefs, id)
+		}
+	}
+	for edge, count := range pairCounts {
+		if count > 1 {
+			report.DuplicateEdges = append(report.DuplicateEdges, edge)
+		}
+	}
+
+	roots := make([]int64, 0)
+	for id := range selfRef {
+		if _, hasParent := directParent[id]; !hasParent {
+			roots = append(roots, id)
+		}
+	}
+
+	reachable := make(map[int64]bool, len(ids))
+	var visit func(id int64)
+	visit = func(id int64) {
+		if reachable[id] {
+			return
+		}
+		reachable[id] = true
+		for _, child := range directChildren[id] {
+			visit(child)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	for id := range ids {
+		if !reachable[id] {
+			report.Orphaned = append(report.Orphaned, id)
+		}
+	}
+
+	report.Cycles = detectCycles(directChildren)
+
+	return report, nil
 }
 
-func (r *ClosureRelation) Move(ctx context.Context, q Querier, nodeID, newParentID int64) error {
-	if _, deleteErr := q.ExecContext(
+// Repair rebuilds the transitive closure from the depth=1 edges using a
+// recursive CTE, discarding and re-deriving every other row. It refuses to
+// run if report contains detected cycles, since a cyclic depth=1 graph has
+// no well-defined transitive closure. The delete and re-insert run inside a
+// transaction when q supports one (see BeginTxer), so a failure partway
+// through can't leave the table empty or half-rebuilt. The re-insert is
+// chunked to defaultBatchSize rows per statement, so rebuilding a large
+// table doesn't exceed the driver's bound-parameter limit in one INSERT.
+func (r *ClosureRelation) Repair(ctx context.Context, q Querier, report *IntegrityReport) error {
+	if report != nil && len(report.Cycles) > 0 {
+		return fmt.Errorf("repair closure table: %d cycle(s) detected, refusing to rebuild", len(report.Cycles))
+	}
+
+	rows, err := q.QueryContext(
 		ctx,
 		fmt.Sprintf(
-			"DELETE FROM %s "+
-				"WHERE %s IN "+
-				"(SELECT %s FROM %s WHERE %s = ?) "+
-				"AND %s IN "+
-				"(SELECT %s FROM %s WHERE %s = ? AND %s != %s) ",
-			r.table,
-			r.attrs[Child],
-			r.attrs[Child], r.table, r.attrs[Parent],
-			r.attrs[Parent],
-			r.attrs[Parent], r.table, r.attrs[Child], r.attrs[Parent], r.attrs[Child],
+			`%[5]s rebuilt(root_id, node_id, lvl) AS (
+				SELECT id, id, 0 FROM (
+					SELECT %[1]s AS id FROM %[2]s
+					UNION
+					SELECT %[3]s AS id FROM %[2]s
+				)
+				UNION ALL
+				SELECT r.root_id, e.%[1]s, r.lvl + 1
+				FROM rebuilt r
+				JOIN %[2]s e ON e.%[3]s = r.node_id
+				WHERE e.%[4]s = 1
+			)
+			SELECT node_id, root_id, lvl FROM rebuilt`,
+			r.qAttr(Child), r.qTable(), r.qAttr(Parent), r.qAttr(Depth), r.dialect.RecursiveCTE(),
 		),
-		nodeID, nodeID,
-	); deleteErr != nil {
-		return fmt.Errorf("remove node ID %d: %w", nodeID, deleteErr)
+	)
+	if err != nil {
+		return fmt.Errorf("compute rebuilt closure: %w", err)
 	}
-
-	parents, err := r.GetParents(ctx, q, newParentID)
+	rebuilt, err := scanNodes(rows)
 	if err != nil {
-		return fmt.Errorf("get new parents for moved nodes: %w", err)
+		return fmt.Errorf("scan rebuilt closure: %w", err)
 	}
-	parentIDs := NodeIDs(parents)
-	parentIDs = append(parentIDs, newParentID)
-	parentIDsPlaceholders := makePlaceholders("?", len(parentIDs))
 
-	children, err := r.GetChildren(ctx, q, nodeID)
-	if err != nil {
-		return fmt.Errorf("get all nodes being moved: %w", err)
-	}
-	childrenIDs := NodeIDs(children)
-	childrenIDsPlaceholders := makePlaceholders("?", len(childrenIDs))
-
-	args := make([]interface{}, len(parentIDs)+len(childrenIDs))
-	for i := 0; i < len(args); i++ {
-		if i < len(parentIDs) {
-			args[i] = parentIDs[i]
-		} else {
-			args[i] = childrenIDs[i-len(parentIDs)]
-		}
-	}
-
-	query := fmt.Sprintf(
-		`INSERT INTO %s (%s, %s, %s)
-        	SELECT supertree.%s, subtree.%s, MAX(supertree.%s + subtree.%s + 1)
-        	FROM %s AS supertree, %s AS subtree
-        	WHERE 
-        	    supertree.%s IN %s
-        		AND subtree.%s IN %s
-			GROUP BY supertree.%s, subtree.%s`,
-		r.table, r.attrs[Parent], r.attrs[Child], r.attrs[Depth],
-		r.attrs[Parent], r.attrs[Child], r.attrs[Depth], r.attrs[Depth],
-		r.table, r.table,
-		r.attrs[Parent], parentIDsPlaceholders,
-		r.attrs[Child], childrenIDsPlaceholders,
-		r.attrs[Parent], r.attrs[Child],
+	rebuiltRows := make([]closureRow, len(rebuilt))
+	for i, n := range rebuilt {
+		rebuiltRows[i] = closureRow{child: n.ID, parent: n.ParentID, depth: n.Depth}
+	}
+
+	return withOptionalTx(ctx, q, nil, func(tq Querier) error {
+		if _, err := tq.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", r.qTable())); err != nil {
+			return fmt.Errorf("clear closure table before repair: %w", err)
+		}
+		for start := 0; start < len(rebuiltRows); start += defaultBatchSize {
+			end := start + defaultBatchSize
+			if end > len(rebuiltRows) {
+				end = len(rebuiltRows)
+			}
+			if err := r.insertClosureRows(ctx, tq, rebuiltRows[start:end]); err != nil {
+				return fmt.Errorf("insert rebuilt closure rows [%d:%d): %w", start, end, err)
+			}
+		}
+		return nil
+	})
+}
+
+// detectCycles finds cycles in the directed graph of direct (depth=1) edges
+// via DFS, returning each cycle as the sequence of node IDs that form it.
+func detectCycles(directChildren map[int64][]int64) [][]int64 {
+	const (
+		white = iota
+		gray
+		black
 	)
+	color := make(map[int64]int, len(directChildren))
+	var stack []int64
+	var cycles [][]int64
 
-	if _, insertErr := q.ExecContext(
-		ctx,
-		query,
-		args...,
-	); insertErr != nil {
-		return fmt.Errorf("insert nodes under new parent: %w", insertErr)
+	var visit func(id int64)
+	visit = func(id int64) {
+		color[id] = gray
+		stack = append(stack, id)
+		for _, next := range directChildren[id] {
+			switch color[next] {
+			case white:
+				visit(next)
+			case gray:
+				for i, id := range stack {
+					if id == next {
+						cycle := append([]int64{}, stack[i:]...)
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		color[id] = black
 	}
 
-	return nil
+	for id := range directChildren {
+		if color[id] == white {
+			visit(id)
+		}
+	}
+	return cycles
 }
 
 func (r *ClosureRelation) Empty(ctx context.Context, q Querier) (bool, error) {
-	row := q.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s", r.table))
+	row := q.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s", r.qTable()))
 	var cnt int
 	if err := row.Scan(&cnt); err != nil {
 		return false, fmt.Errorf("count closure table rows: %w", err)
@@ -233,6 +862,29 @@ func scanNodes(rows *sql.Rows) ([]Node, error) {
 	return result, nil
 }
 
+func scanNodesWithPath(rows *sql.Rows) ([]NodeWithPath, error) {
+	result := make([]NodeWithPath, 0)
+	if _, scanErr := scanEachRow(rows, func(s scanner) error {
+		n := NodeWithPath{}
+		var path string
+		if rowErr := s.Scan(&n.ID, &n.ParentID, &path, &n.Level); rowErr != nil {
+			return rowErr
+		}
+		for _, part := range strings.Split(path, "/") {
+			id, convErr := strconv.ParseInt(part, 10, 64)
+			if convErr != nil {
+				return fmt.Errorf("parse path segment %q: %w", part, convErr)
+			}
+			n.Path = append(n.Path, id)
+		}
+		result = append(result, n)
+		return nil
+	}); scanErr != nil {
+		return nil, fmt.Errorf("scan nodes with path: %w", scanErr)
+	}
+	return result, nil
+}
+
 func scanEachRow(rows *sql.Rows, scanRow func(s scanner) error) (rowsProcessed int, err error) {
 	defer func() { _ = rows.Close() }()
 	count := 0
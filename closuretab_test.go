@@ -144,6 +144,209 @@ func (s *closureSuite) TestMove() {
 	)
 }
 
+func (s *closureSuite) TestInsertBatch() {
+	cl := initClosure()
+	ctx := context.Background()
+
+	s.Require().NoError(cl.InsertBatch(ctx, s.db, []Edge{{ParentID: 0, ChildID: 0}}, 0))
+	s.Require().NoError(cl.InsertBatch(ctx, s.db, []Edge{
+		{ParentID: 0, ChildID: 1},
+		{ParentID: 1, ChildID: 2},
+	}, 0))
+
+	ch, err := cl.GetChildren(ctx, s.db, 0)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch(
+		[]Node{
+			{ID: 0, ParentID: 0, Depth: 0},
+			{ID: 1, ParentID: 0, Depth: 1},
+			{ID: 2, ParentID: 0, Depth: 2},
+		},
+		ch,
+	)
+}
+
+// TestInsertBatchChunksByRowCount guards against chunking InsertBatch's
+// INSERTs by edge count: a chain of N nodes has O(N^2) ancestor rows total,
+// so even a handful of edges deep in the chain can emit more rows than a
+// small batchSize allows in one statement. Using a small batchSize (instead
+// of a 10000-node chain) keeps the test fast while still forcing many
+// chunked flushes partway through processing a single edge's ancestor set.
+func (s *closureSuite) TestInsertBatchChunksByRowCount() {
+	cl := initClosure()
+	ctx := context.Background()
+
+	const n = 200
+	s.Require().NoError(cl.InsertBatch(ctx, s.db, []Edge{{ParentID: 0, ChildID: 0}}, 0))
+	s.Require().NoError(cl.InsertBatch(ctx, s.db, chainEdges(n), 8))
+
+	ch, err := cl.GetChildren(ctx, s.db, 0)
+	s.Require().NoError(err)
+	s.Require().Len(ch, n)
+
+	deepest, err := cl.GetParents(ctx, s.db, int64(n-1))
+	s.Require().NoError(err)
+	s.Require().Len(deepest, n-1)
+}
+
+func (s *closureSuite) TestBulkLoad() {
+	cl := initClosure()
+	ctx := context.Background()
+
+	s.Require().NoError(cl.BulkLoad(ctx, s.db, []Edge{
+		{ParentID: 1, ChildID: 2},
+		{ParentID: 0, ChildID: 1},
+		{ParentID: 1, ChildID: 3},
+	}, 0))
+
+	ch, err := cl.GetChildren(ctx, s.db, 0)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch(
+		[]Node{
+			{ID: 0, ParentID: 0, Depth: 0},
+			{ID: 1, ParentID: 0, Depth: 1},
+			{ID: 2, ParentID: 0, Depth: 2},
+			{ID: 3, ParentID: 0, Depth: 2},
+		},
+		ch,
+	)
+}
+
+func (s *closureSuite) TestMoveRejectsCycle() {
+	cl := initClosure()
+	ctx := context.Background()
+
+	_, err := cl.Insert(ctx, s.db, 0, 0)
+	s.Require().NoError(err)
+	_, err = cl.Insert(ctx, s.db, 0, 1)
+	s.Require().NoError(err)
+	_, err = cl.Insert(ctx, s.db, 1, 2)
+	s.Require().NoError(err)
+
+	err = cl.Move(ctx, s.db, 1, 2)
+	s.Require().Error(err)
+}
+
+func (s *closureSuite) TestValidateAndRepair() {
+	cl := initClosure()
+	ctx := context.Background()
+
+	_, err := cl.Insert(ctx, s.db, 0, 0)
+	s.Require().NoError(err)
+	_, err = cl.Insert(ctx, s.db, 0, 1)
+	s.Require().NoError(err)
+	_, err = cl.Insert(ctx, s.db, 1, 2)
+	s.Require().NoError(err)
+
+	report, err := cl.Validate(ctx, s.db)
+	s.Require().NoError(err)
+	s.Require().True(report.Clean())
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM closure WHERE id = 0 AND depth = 0")
+	s.Require().NoError(err)
+
+	report, err = cl.Validate(ctx, s.db)
+	s.Require().NoError(err)
+	s.Require().False(report.Clean())
+	s.Require().Contains(report.MissingSelfRefs, int64(0))
+
+	s.Require().NoError(cl.Repair(ctx, s.db, report))
+
+	report, err = cl.Validate(ctx, s.db)
+	s.Require().NoError(err)
+	s.Require().True(report.Clean())
+
+	ch, err := cl.GetChildren(ctx, s.db, 0)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch(
+		[]Node{
+			{ID: 0, ParentID: 0, Depth: 0},
+			{ID: 1, ParentID: 0, Depth: 1},
+			{ID: 2, ParentID: 0, Depth: 2},
+		},
+		ch,
+	)
+}
+
+// TestRepairChunksLargeRebuild guards against Repair reinserting its entire
+// rebuilt closure as one multi-row INSERT: at a few thousand rows that
+// exceeds the driver's bound-parameter limit, which is exactly the size
+// regime Repair exists to recover.
+func (s *closureSuite) TestRepairChunksLargeRebuild() {
+	cl := initClosure()
+	ctx := context.Background()
+
+	const n = 3000
+	edges := make([]Edge, n)
+	for i := 0; i < n; i++ {
+		edges[i] = Edge{ParentID: 0, ChildID: int64(i + 1)}
+	}
+	s.Require().NoError(cl.InsertBatch(ctx, s.db, []Edge{{ParentID: 0, ChildID: 0}}, 0))
+	s.Require().NoError(cl.InsertBatch(ctx, s.db, edges, 0))
+
+	report, err := cl.Validate(ctx, s.db)
+	s.Require().NoError(err)
+	s.Require().True(report.Clean())
+
+	s.Require().NoError(cl.Repair(ctx, s.db, report))
+
+	ch, err := cl.GetChildren(ctx, s.db, 0)
+	s.Require().NoError(err)
+	s.Require().Len(ch, n+1)
+}
+
+func (s *closureSuite) TestGetSubtree() {
+	cl := initClosure()
+	ctx := context.Background()
+
+	_, err := cl.Insert(ctx, s.db, 0, 0)
+	s.Require().NoError(err)
+	_, err = cl.Insert(ctx, s.db, 0, 1)
+	s.Require().NoError(err)
+	_, err = cl.Insert(ctx, s.db, 1, 2)
+	s.Require().NoError(err)
+	_, err = cl.Insert(ctx, s.db, 0, 3)
+	s.Require().NoError(err)
+
+	nodes, err := cl.GetSubtree(ctx, s.db, 0)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch(
+		[]NodeWithPath{
+			{ID: 1, ParentID: 0, Path: []int64{1}, Level: 1},
+			{ID: 3, ParentID: 0, Path: []int64{3}, Level: 1},
+			{ID: 2, ParentID: 1, Path: []int64{1, 2}, Level: 2},
+		},
+		nodes,
+	)
+
+	tree := BuildTree(nodes)
+	s.Require().Len(tree, 2)
+}
+
+// TestGetSubtreeOrdersNumerically guards against sorting the path as a
+// string, which puts "10" before "2".
+func (s *closureSuite) TestGetSubtreeOrdersNumerically() {
+	cl := initClosure()
+	ctx := context.Background()
+
+	_, err := cl.Insert(ctx, s.db, 0, 0)
+	s.Require().NoError(err)
+	_, err = cl.Insert(ctx, s.db, 0, 2)
+	s.Require().NoError(err)
+	_, err = cl.Insert(ctx, s.db, 0, 10)
+	s.Require().NoError(err)
+
+	nodes, err := cl.GetSubtree(ctx, s.db, 0)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		[]NodeWithPath{
+			{ID: 2, ParentID: 0, Path: []int64{2}, Level: 1},
+			{ID: 10, ParentID: 0, Path: []int64{10}, Level: 1},
+		},
+		nodes,
+	)
+}
+
 func (s *closureSuite) SetupTest() {
 	db, err := sql.Open("sqlite", ":memory:")
 	s.Require().NoError(err)
@@ -177,3 +380,85 @@ func initClosure() *ClosureRelation {
 		},
 	)
 }
+
+// chainEdges builds a degenerate n-node chain 0 -> 1 -> 2 -> ... -> n-1, the
+// worst case for Insert's per-node round trips.
+func chainEdges(n int) []Edge {
+	edges := make([]Edge, 0, n-1)
+	for i := int64(1); i < int64(n); i++ {
+		edges = append(edges, Edge{ParentID: i - 1, ChildID: i})
+	}
+	return edges
+}
+
+func openBenchDB(b *testing.B) *sql.DB {
+	b.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		b.Fatal(err)
+	}
+	mm := []dbmigrate.Migration{
+		{
+			ID:   "1",
+			Desc: "initial",
+			Stmt: `CREATE TABLE closure (id INTEGER, parent_id INTEGER, depth INTEGER);`,
+		},
+	}
+	if err := dbmigrate.UpgradeToLatest(db, dbmigrate.DialectSQLite, mm...); err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+func BenchmarkInsertChain(b *testing.B) {
+	const n = 10000
+	edges := chainEdges(n)
+	cl := initClosure()
+	ctx := context.Background()
+
+	for i := 0; i < b.N; i++ {
+		db := openBenchDB(b)
+		if _, err := cl.Insert(ctx, db, 0, 0); err != nil {
+			b.Fatal(err)
+		}
+		for _, e := range edges {
+			if _, err := cl.Insert(ctx, db, e.ParentID, e.ChildID); err != nil {
+				b.Fatal(err)
+			}
+		}
+		_ = db.Close()
+	}
+}
+
+func BenchmarkBulkLoadChain(b *testing.B) {
+	const n = 10000
+	edges := chainEdges(n)
+	cl := initClosure()
+	ctx := context.Background()
+
+	for i := 0; i < b.N; i++ {
+		db := openBenchDB(b)
+		if err := cl.BulkLoad(ctx, db, edges, 0); err != nil {
+			b.Fatal(err)
+		}
+		_ = db.Close()
+	}
+}
+
+func BenchmarkInsertBatchChain(b *testing.B) {
+	const n = 10000
+	edges := chainEdges(n)
+	cl := initClosure()
+	ctx := context.Background()
+
+	for i := 0; i < b.N; i++ {
+		db := openBenchDB(b)
+		if err := cl.InsertBatch(ctx, db, []Edge{{ParentID: 0, ChildID: 0}}, 0); err != nil {
+			b.Fatal(err)
+		}
+		if err := cl.InsertBatch(ctx, db, edges, 0); err != nil {
+			b.Fatal(err)
+		}
+		_ = db.Close()
+	}
+}
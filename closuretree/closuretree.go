@@ -0,0 +1,140 @@
+// Package closuretree provides Tree[T], a typed wrapper that joins a
+// user-defined payload table to a closure table managed by closuretab, so
+// callers don't have to hand-roll payload SQL alongside closure-table SQL.
+package closuretree
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/vlanse/closuretab"
+)
+
+// ScanFunc builds one payload value T from a single row of a *sql.Rows
+// positioned at that row.
+type ScanFunc[T any] func(rows *sql.Rows) (T, error)
+
+// InsertFunc inserts a payload row for T and returns its generated ID,
+// which becomes the node ID used in the closure table.
+type InsertFunc[T any] func(ctx context.Context, q closuretab.Querier, payload T) (int64, error)
+
+// PayloadMapping describes the user-defined table that stores T, so Tree[T]
+// can join it to the closure table without knowing its schema up front.
+type PayloadMapping[T any] struct {
+	Table    string
+	PKColumn string
+	Scan     ScanFunc[T]
+	Insert   InsertFunc[T]
+}
+
+// Tree combines a raw closuretab.ClosureRelation with a PayloadMapping so
+// callers get a typed API instead of juggling closure-table rows and
+// payload rows separately.
+type Tree[T any] struct {
+	closure *closuretab.ClosureRelation
+	payload PayloadMapping[T]
+}
+
+func New[T any](closure *closuretab.ClosureRelation, payload PayloadMapping[T]) *Tree[T] {
+	return &Tree[T]{closure: closure, payload: payload}
+}
+
+// Insert inserts a new payload row under parentID and records the
+// corresponding closure-table edges for it.
+func (t *Tree[T]) Insert(ctx context.Context, q closuretab.Querier, parentID int64, payload T) (int64, error) {
+	id, err := t.payload.Insert(ctx, q, payload)
+	if err != nil {
+		return 0, fmt.Errorf("insert payload row: %w", err)
+	}
+	if _, err := t.closure.Insert(ctx, q, parentID, id); err != nil {
+		return 0, fmt.Errorf("insert closure edges for node ID %d: %w", id, err)
+	}
+	return id, nil
+}
+
+// GetChildrenTyped returns the payload rows for parentID itself and its
+// direct and transitive children, as reported by ClosureRelation.GetChildren.
+func (t *Tree[T]) GetChildrenTyped(ctx context.Context, q closuretab.Querier, parentID int64) ([]T, error) {
+	children, err := t.closure.GetChildren(ctx, q, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("get children of node ID %d: %w", parentID, err)
+	}
+	return t.scanPayloads(ctx, q, closuretab.NodeIDs(children))
+}
+
+// GetSubtreeTyped returns the payload rows for rootID's descendants, in the
+// same order as ClosureRelation.GetSubtree.
+func (t *Tree[T]) GetSubtreeTyped(ctx context.Context, q closuretab.Querier, rootID int64) ([]T, error) {
+	nodes, err := t.closure.GetSubtree(ctx, q, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("get subtree of node ID %d: %w", rootID, err)
+	}
+	ids := make([]int64, len(nodes))
+	for i := range nodes {
+		ids[i] = nodes[i].ID
+	}
+	payloads, err := t.scanPayloads(ctx, q, ids)
+	if err != nil {
+		return nil, err
+	}
+	return payloads, nil
+}
+
+// Move relocates nodeID, and everything beneath it, under newParentID.
+func (t *Tree[T]) Move(ctx context.Context, q closuretab.Querier, nodeID, newParentID int64) error {
+	return t.closure.Move(ctx, q, nodeID, newParentID)
+}
+
+// scanPayloads queries the payload table for ids and returns the results in
+// the same order as ids, using a CASE-ordered ORDER BY rather than relying
+// on IN (...) to preserve order, since it doesn't on most engines. Queries
+// are built with "?" placeholders and bound through t.closure's dialect, so
+// Tree[T] works against whatever database the ClosureRelation it wraps was
+// configured for.
+func (t *Tree[T]) scanPayloads(ctx context.Context, q closuretab.Querier, ids []int64) ([]T, error) {
+	if len(ids) == 0 {
+		return []T{}, nil
+	}
+
+	table := t.closure.QuoteIdent(t.payload.Table)
+	pk := t.closure.QuoteIdent(t.payload.PKColumn)
+
+	placeholders := make([]string, len(ids))
+	orderCases := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	orderArgs := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+		orderCases[i] = fmt.Sprintf("WHEN %s = ? THEN %d", pk, i)
+		orderArgs[i] = id
+	}
+
+	rows, err := q.QueryContext(
+		ctx,
+		t.closure.Bind(fmt.Sprintf(
+			"SELECT * FROM %s WHERE %s IN (%s) ORDER BY CASE %s END",
+			table, pk, strings.Join(placeholders, ", "), strings.Join(orderCases, " "),
+		)),
+		append(args, orderArgs...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query payload rows: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := make([]T, 0, len(ids))
+	for rows.Next() {
+		v, scanErr := t.payload.Scan(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("scan payload row: %w", scanErr)
+		}
+		result = append(result, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan payload rows: %w", err)
+	}
+	return result, nil
+}
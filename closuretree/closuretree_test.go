@@ -0,0 +1,124 @@
+package closuretree
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/vlanse/closuretab"
+	"github.com/vlanse/dbmigrate"
+	_ "modernc.org/sqlite"
+)
+
+type node struct {
+	ID   int64
+	Name string
+}
+
+type treeSuite struct {
+	suite.Suite
+	db *sql.DB
+}
+
+func TestTree(t *testing.T) {
+	suite.Run(t, &treeSuite{})
+}
+
+func (s *treeSuite) TestInsertAndGetChildrenTyped() {
+	tr := initTree()
+	ctx := context.Background()
+
+	rootID, err := tr.Insert(ctx, s.db, 0, node{Name: "root"})
+	s.Require().NoError(err)
+
+	_, err = tr.Insert(ctx, s.db, rootID, node{Name: "child"})
+	s.Require().NoError(err)
+
+	children, err := tr.GetChildrenTyped(ctx, s.db, rootID)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch(
+		[]node{{ID: rootID, Name: "root"}, {ID: rootID + 1, Name: "child"}},
+		children,
+	)
+}
+
+func (s *treeSuite) TestGetSubtreeTypedPreservesOrder() {
+	tr := initTree()
+	ctx := context.Background()
+
+	rootID, err := tr.Insert(ctx, s.db, 0, node{Name: "root"})
+	s.Require().NoError(err)
+	oneID, err := tr.Insert(ctx, s.db, rootID, node{Name: "one"})
+	s.Require().NoError(err)
+	_, err = tr.Insert(ctx, s.db, oneID, node{Name: "two"})
+	s.Require().NoError(err)
+	_, err = tr.Insert(ctx, s.db, rootID, node{Name: "three"})
+	s.Require().NoError(err)
+
+	nodes, err := tr.closure.GetSubtree(ctx, s.db, rootID)
+	s.Require().NoError(err)
+
+	subtree, err := tr.GetSubtreeTyped(ctx, s.db, rootID)
+	s.Require().NoError(err)
+
+	s.Require().Len(subtree, len(nodes))
+	for i, n := range nodes {
+		s.Require().Equal(n.ID, subtree[i].ID)
+	}
+}
+
+func (s *treeSuite) SetupTest() {
+	db, err := sql.Open("sqlite", ":memory:")
+	s.Require().NoError(err)
+
+	s.db = db
+
+	mm := []dbmigrate.Migration{
+		{
+			ID:   "1",
+			Desc: "initial",
+			Stmt: `
+				CREATE TABLE closure
+				(
+					id INTEGER,
+					parent_id INTEGER,
+					depth INTEGER
+				);
+				CREATE TABLE nodes
+				(
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					name TEXT
+				);
+				`,
+		},
+	}
+	s.Require().NoError(dbmigrate.UpgradeToLatest(s.db, dbmigrate.DialectSQLite, mm...))
+}
+
+func initTree() *Tree[node] {
+	closure := closuretab.InitClosureRelation(
+		"closure",
+		closuretab.AttrMapping{
+			closuretab.Child:  "id",
+			closuretab.Parent: "parent_id",
+			closuretab.Depth:  "depth",
+		},
+	)
+	return New(closure, PayloadMapping[node]{
+		Table:    "nodes",
+		PKColumn: "id",
+		Scan: func(rows *sql.Rows) (node, error) {
+			var n node
+			err := rows.Scan(&n.ID, &n.Name)
+			return n, err
+		},
+		Insert: func(ctx context.Context, q closuretab.Querier, payload node) (int64, error) {
+			res, err := q.ExecContext(ctx, "INSERT INTO nodes (name) VALUES (?)", payload.Name)
+			if err != nil {
+				return 0, err
+			}
+			return res.LastInsertId()
+		},
+	})
+}
@@ -0,0 +1,138 @@
+package closuretab
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect adapts ClosureRelation's SQL generation to a specific database
+// engine: placeholder syntax, identifier quoting, and the handful of
+// constructs (recursive CTEs, ignore-duplicate inserts) whose syntax
+// differs across engines. Queries are always authored against "?"
+// placeholders and bare identifiers; rebind and QuoteIdent translate them
+// into the active dialect right before a statement is sent to the driver.
+type Dialect interface {
+	// Placeholder returns the bound-parameter marker for the n-th (1-based)
+	// positional argument in a statement.
+	Placeholder(n int) string
+	// QuoteIdent quotes a table or column name for safe use as an
+	// identifier in this dialect.
+	QuoteIdent(name string) string
+	// RecursiveCTE returns the keyword(s) introducing a recursive common
+	// table expression.
+	RecursiveCTE() string
+	// UpsertIgnore returns this dialect's duplicate-ignoring insert syntax:
+	// a keyword spliced between INSERT and INTO for SQLite ("OR IGNORE")
+	// and MySQL ("IGNORE"), or a clause appended after the statement for
+	// Postgres ("ON CONFLICT DO NOTHING").
+	UpsertIgnore() string
+	// PathExprs returns the SQL used by GetSubtree's recursive CTE to
+	// accumulate an ancestor path, in this dialect's syntax: seed is the
+	// expression for a root row's single-element path, step extends the
+	// recursive term's s.path with e.<childAttr>, and final projects the
+	// accumulated path as a '/'-delimited string (a no-op unless, as for
+	// Postgres, the path is built as a native array rather than text).
+	PathExprs(childAttr string) (seed, step, final string)
+}
+
+type sqliteDialect struct{}
+
+// DialectSQLite is the default Dialect, matching this package's original
+// SQLite-only behaviour.
+var DialectSQLite Dialect = sqliteDialect{}
+
+func (sqliteDialect) Placeholder(int) string        { return "?" }
+func (sqliteDialect) QuoteIdent(name string) string { return name }
+func (sqliteDialect) RecursiveCTE() string          { return "WITH RECURSIVE" }
+func (sqliteDialect) UpsertIgnore() string          { return "OR IGNORE" }
+
+func (sqliteDialect) PathExprs(childAttr string) (seed, step, final string) {
+	return fmt.Sprintf("CAST(%s AS TEXT)", childAttr),
+		fmt.Sprintf("s.path || '/' || CAST(e.%s AS TEXT)", childAttr),
+		"path"
+}
+
+type postgresDialect struct{}
+
+// DialectPostgres emits $1, $2, ... placeholders, double-quoted
+// identifiers, and ON CONFLICT DO NOTHING in place of SQLite's
+// INSERT OR IGNORE.
+var DialectPostgres Dialect = postgresDialect{}
+
+func (postgresDialect) Placeholder(n int) string    { return "$" + strconv.Itoa(n) }
+func (postgresDialect) QuoteIdent(name string) string { return `"` + name + `"` }
+func (postgresDialect) RecursiveCTE() string        { return "WITH RECURSIVE" }
+func (postgresDialect) UpsertIgnore() string        { return "ON CONFLICT DO NOTHING" }
+
+func (postgresDialect) PathExprs(childAttr string) (seed, step, final string) {
+	return fmt.Sprintf("array[%s::text]", childAttr),
+		fmt.Sprintf("s.path || array[e.%s::text]", childAttr),
+		"array_to_string(path, '/')"
+}
+
+type mysqlDialect struct{}
+
+// DialectMySQL emits backtick-quoted identifiers and INSERT IGNORE in
+// place of SQLite's INSERT OR IGNORE.
+var DialectMySQL Dialect = mysqlDialect{}
+
+func (mysqlDialect) Placeholder(int) string         { return "?" }
+func (mysqlDialect) QuoteIdent(name string) string { return "`" + name + "`" }
+func (mysqlDialect) RecursiveCTE() string          { return "WITH RECURSIVE" }
+func (mysqlDialect) UpsertIgnore() string          { return "IGNORE" }
+
+func (mysqlDialect) PathExprs(childAttr string) (seed, step, final string) {
+	return fmt.Sprintf("CAST(%s AS CHAR)", childAttr),
+		fmt.Sprintf("CONCAT(s.path, '/', CAST(e.%s AS CHAR))", childAttr),
+		"path"
+}
+
+// rebind rewrites a query built with "?" placeholders into d's placeholder
+// syntax, renumbering sequentially from 1.
+func rebind(d Dialect, query string) string {
+	if !strings.ContainsRune(query, '?') {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Bind rewrites a query written with "?" placeholders into r's configured
+// dialect's placeholder syntax. Exported so callers building their own
+// queries alongside a ClosureRelation, such as closuretree.Tree's payload
+// queries, don't have to duplicate dialect handling.
+func (r *ClosureRelation) Bind(query string) string {
+	return r.bind(query)
+}
+
+// QuoteIdent quotes name as a table or column identifier in r's configured
+// dialect.
+func (r *ClosureRelation) QuoteIdent(name string) string {
+	return r.dialect.QuoteIdent(name)
+}
+
+// Schema returns the CREATE TABLE statement and the (parent,depth) and
+// (child,depth) index statements this closure table needs, in r's dialect.
+func (r *ClosureRelation) Schema() []string {
+	create := "CREATE TABLE " + r.qTable() + " (" +
+		r.qAttr(Child) + " INTEGER NOT NULL, " +
+		r.qAttr(Parent) + " INTEGER NOT NULL, " +
+		r.qAttr(Depth) + " INTEGER NOT NULL)"
+
+	parentDepthIdx := "CREATE INDEX " + r.dialect.QuoteIdent(r.table+"_parent_depth_idx") +
+		" ON " + r.qTable() + " (" + r.qAttr(Parent) + ", " + r.qAttr(Depth) + ")"
+	childDepthIdx := "CREATE INDEX " + r.dialect.QuoteIdent(r.table+"_child_depth_idx") +
+		" ON " + r.qTable() + " (" + r.qAttr(Child) + ", " + r.qAttr(Depth) + ")"
+
+	return []string{create, parentDepthIdx, childDepthIdx}
+}
@@ -0,0 +1,76 @@
+package closuretab
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostgresDialectQuotesAndRebindsPlaceholders(t *testing.T) {
+	cl := InitClosureRelation(
+		"closure",
+		AttrMapping{Child: "id", Parent: "parent_id", Depth: "depth"},
+		WithDialect(DialectPostgres),
+	)
+
+	if got := cl.qTable(); got != `"closure"` {
+		t.Fatalf("qTable() = %q, want %q", got, `"closure"`)
+	}
+	if got := cl.qAttr(Parent); got != `"parent_id"` {
+		t.Fatalf("qAttr(Parent) = %q, want %q", got, `"parent_id"`)
+	}
+
+	got := cl.bind("SELECT 1 WHERE a = ? AND b = ?")
+	want := "SELECT 1 WHERE a = $1 AND b = $2"
+	if got != want {
+		t.Fatalf("bind() = %q, want %q", got, want)
+	}
+}
+
+func TestMySQLDialectQuotesIdentifiers(t *testing.T) {
+	cl := InitClosureRelation(
+		"closure",
+		AttrMapping{Child: "id", Parent: "parent_id", Depth: "depth"},
+		WithDialect(DialectMySQL),
+	)
+
+	if got := cl.qTable(); got != "`closure`" {
+		t.Fatalf("qTable() = %q, want %q", got, "`closure`")
+	}
+	if got := cl.bind("SELECT 1 WHERE a = ?"); got != "SELECT 1 WHERE a = ?" {
+		t.Fatalf("bind() = %q, want unchanged query", got)
+	}
+}
+
+func TestPathExprsPerDialect(t *testing.T) {
+	for _, d := range []Dialect{DialectSQLite, DialectPostgres, DialectMySQL} {
+		seed, step, final := d.PathExprs("id")
+		if seed == "" || step == "" || final == "" {
+			t.Fatalf("%T.PathExprs() returned an empty fragment: seed=%q step=%q final=%q", d, seed, step, final)
+		}
+	}
+
+	if _, _, final := DialectMySQL.PathExprs("id"); final != "path" {
+		t.Fatalf("MySQL PathExprs() final = %q, want %q", final, "path")
+	}
+	if seed, _, _ := DialectMySQL.PathExprs("id"); strings.Contains(seed, "TEXT") {
+		t.Fatalf("MySQL PathExprs() seed = %q, CAST(... AS TEXT) isn't valid MySQL syntax", seed)
+	}
+	if _, step, _ := DialectMySQL.PathExprs("id"); !strings.Contains(step, "CONCAT") {
+		t.Fatalf("MySQL PathExprs() step = %q, want CONCAT-based concatenation (MySQL's || means logical OR by default)", step)
+	}
+}
+
+func TestSchemaPerDialect(t *testing.T) {
+	attrs := AttrMapping{Child: "id", Parent: "parent_id", Depth: "depth"}
+
+	sqlite := InitClosureRelation("closure", attrs)
+	stmts := sqlite.Schema()
+	if len(stmts) != 3 {
+		t.Fatalf("Schema() returned %d statements, want 3", len(stmts))
+	}
+
+	postgres := InitClosureRelation("closure", attrs, WithDialect(DialectPostgres))
+	if got := postgres.Schema()[0]; got == stmts[0] {
+		t.Fatalf("Postgres Schema() should quote identifiers differently than SQLite, got identical: %q", got)
+	}
+}
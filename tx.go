@@ -0,0 +1,64 @@
+package closuretab
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// BeginTxer is implemented by a Querier that can start a transaction, such
+// as *sql.DB. Insert, Delete, and Move use it to detect whether they were
+// handed a plain connection pool, in which case they open their own
+// transaction around their multi-statement sequence, or a *sql.Tx (which
+// doesn't implement BeginTxer), in which case they run against it directly
+// and inherit the caller's transaction.
+type BeginTxer interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// WithTx runs fn inside a transaction opened on db, committing if fn
+// returns nil and rolling back otherwise. Use it to group several
+// ClosureRelation calls (or a call alongside payload-table writes) into one
+// atomic operation; Insert, Delete, and Move already do this internally for
+// their own statements when passed a *sql.DB directly.
+func WithTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context, q Querier) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	if err := fn(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// withOptionalTx runs fn against q, opening a transaction first if q
+// implements BeginTxer, so q's own call inherits the caller's transaction
+// when one is already in progress.
+func withOptionalTx(ctx context.Context, q Querier, opts *sql.TxOptions, fn func(q Querier) error) error {
+	beginner, ok := q.(BeginTxer)
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package closuretab
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/vlanse/dbmigrate"
+	_ "modernc.org/sqlite"
+)
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mm := []dbmigrate.Migration{
+		{
+			ID:   "1",
+			Desc: "initial",
+			Stmt: `CREATE TABLE closure (id INTEGER, parent_id INTEGER, depth INTEGER);`,
+		},
+	}
+	if err := dbmigrate.UpgradeToLatest(db, dbmigrate.DialectSQLite, mm...); err != nil {
+		t.Fatal(err)
+	}
+
+	cl := initClosure()
+	ctx := context.Background()
+
+	boom := errors.New("boom")
+	err = WithTx(ctx, db, func(ctx context.Context, q Querier) error {
+		if _, err := cl.Insert(ctx, q, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("WithTx() error = %v, want %v", err, boom)
+	}
+
+	empty, err := cl.Empty(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !empty {
+		t.Fatal("expected closure table to be empty after a rolled-back transaction")
+	}
+}